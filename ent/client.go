@@ -0,0 +1,317 @@
+package ent
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Client дает типобезопасный доступ к таблицам players/achievements_awarded/games в духе
+// клиента, который сгенерировал бы ent из схемы в ent/openapi.yaml — entgo.io/ent@v0.14 требует
+// Go >= 1.24, на который этот модуль (go 1.21) пока не переходит, поэтому тот же набор сущностей
+// и методов для Player/Achievement/GameSession реализован здесь вручную поверх database/sql.
+type Client struct {
+	db *sql.DB
+}
+
+// NewClient оборачивает уже открытое соединение с БД в типобезопасный клиент
+func NewClient(db *sql.DB) *Client {
+	return &Client{db: db}
+}
+
+func (c *Client) Player() *PlayerClient           { return &PlayerClient{db: c.db} }
+func (c *Client) Achievement() *AchievementClient { return &AchievementClient{db: c.db} }
+func (c *Client) GameSession() *GameSessionClient { return &GameSessionClient{db: c.db} }
+
+// Player — типизированное представление строки таблицы players, с достижениями,
+// подгружаемыми через Edges при запросе с WithAchievements
+type Player struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Score       int         `json:"score"`
+	Coins       int         `json:"coins"`
+	GamesPlayed int         `json:"gamesPlayed"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	Edges       PlayerEdges `json:"edges,omitempty"`
+}
+
+// PlayerEdges хранит связанные сущности, подгруженные вместе с Player
+type PlayerEdges struct {
+	Achievements []*Achievement `json:"achievements,omitempty"`
+}
+
+// Achievement — типизированное представление строки таблицы achievements_awarded
+type Achievement struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	PlayerID  int       `json:"playerId"`
+	AwardedAt time.Time `json:"awardedAt"`
+}
+
+// GameSession — типизированное представление строки таблицы games
+type GameSession struct {
+	ID         int        `json:"id"`
+	Difficulty string     `json:"difficulty"`
+	TimeLimit  int        `json:"timeLimit"`
+	Started    bool       `json:"started"`
+	Finished   bool       `json:"finished"`
+	StartTime  time.Time  `json:"startTime"`
+	EndTime    *time.Time `json:"endTime,omitempty"`
+}
+
+// PlayerClient — типизированные операции над таблицей players
+type PlayerClient struct {
+	db *sql.DB
+}
+
+// Create вставляет нового игрока с значениями по умолчанию, как и раньше делал
+// createPlayerHandler через db.QueryRow напрямую
+func (pc *PlayerClient) Create(name string) (*Player, error) {
+	p := &Player{
+		Name:      name,
+		Coins:     1000,
+		CreatedAt: time.Now(),
+	}
+
+	err := pc.db.QueryRow(`
+		INSERT INTO players (name, score, coins, GamesPlayed, CreatedAt)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`, p.Name, p.Score, p.Coins, p.GamesPlayed, p.CreatedAt.Format(time.RFC3339)).Scan(&p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Get возвращает игрока по ID без подгрузки достижений
+func (pc *PlayerClient) Get(id int) (*Player, error) {
+	p := &Player{ID: id}
+	var createdAt string
+	err := pc.db.QueryRow(`
+		SELECT name, score, coins, GamesPlayed, CreatedAt
+		FROM players WHERE id = ?
+	`, id).Scan(&p.Name, &p.Score, &p.Coins, &p.GamesPlayed, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+		p.CreatedAt = t
+	}
+	return p, nil
+}
+
+// All возвращает всех игроков, отсортированных по очкам по убыванию, без пагинации —
+// используется старым /players, который эту пагинацию клиентам никогда не предлагал
+func (pc *PlayerClient) All() ([]*Player, error) {
+	rows, err := pc.db.Query(`
+		SELECT id, name, score, coins, GamesPlayed
+		FROM players
+		ORDER BY score DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Player
+	for rows.Next() {
+		p := &Player{}
+		if err := rows.Scan(&p.ID, &p.Name, &p.Score, &p.Coins, &p.GamesPlayed); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, rows.Err()
+}
+
+// PlayerListOptions описывает фильтрацию, пагинацию и eager loading для PlayerClient.List —
+// набор параметров соответствует тому, что ogent сгенерировал бы из listPlayer в openapi.yaml
+type PlayerListOptions struct {
+	Page             int
+	ItemsPerPage     int
+	Rating           *int
+	WithAchievements bool
+}
+
+// List возвращает страницу игроков, отсортированных по очкам по убыванию, вместе с общим
+// количеством строк, удовлетворяющих фильтру (для PlayerList.totalItems в openapi.yaml)
+func (pc *PlayerClient) List(opts PlayerListOptions) ([]*Player, int, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.ItemsPerPage < 1 {
+		opts.ItemsPerPage = 20
+	}
+
+	// Фильтр по точному значению столбца rating таблицы players
+	where := ""
+	args := []interface{}{}
+	if opts.Rating != nil {
+		where = "WHERE rating = ?"
+		args = append(args, *opts.Rating)
+	}
+
+	var total int
+	if err := pc.db.QueryRow(`SELECT COUNT(*) FROM players `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, opts.ItemsPerPage, (opts.Page-1)*opts.ItemsPerPage)
+	rows, err := pc.db.Query(`
+		SELECT id, name, score, coins, GamesPlayed, CreatedAt
+		FROM players
+		`+where+`
+		ORDER BY score DESC
+		LIMIT ? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*Player
+	for rows.Next() {
+		p := &Player{}
+		var createdAt string
+		if err := rows.Scan(&p.ID, &p.Name, &p.Score, &p.Coins, &p.GamesPlayed, &createdAt); err != nil {
+			return nil, 0, err
+		}
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			p.CreatedAt = t
+		}
+		result = append(result, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if opts.WithAchievements {
+		for _, p := range result {
+			achievements, err := (&AchievementClient{db: pc.db}).ListByPlayer(p.ID)
+			if err != nil {
+				return nil, 0, err
+			}
+			p.Edges.Achievements = achievements
+		}
+	}
+
+	return result, total, nil
+}
+
+// AchievementClient — типизированные операции над таблицей achievements_awarded
+type AchievementClient struct {
+	db *sql.DB
+}
+
+// Create записывает достижение игрока, как и раньше делал recordAchievementAward через db.Exec
+// напрямую; ON CONFLICT DO NOTHING сохраняет идемпотентность повторного начисления того же
+// достижения
+func (ac *AchievementClient) Create(playerID int, title string) error {
+	_, err := ac.db.Exec(`
+		INSERT INTO achievements_awarded (user_id, title, awarded_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(user_id, title) DO NOTHING
+	`, playerID, title, time.Now().Format(time.RFC3339))
+	return err
+}
+
+// ListByPlayer возвращает достижения одного игрока для eager loading в PlayerClient.List
+func (ac *AchievementClient) ListByPlayer(playerID int) ([]*Achievement, error) {
+	rows, err := ac.db.Query(`
+		SELECT rowid, title, user_id, awarded_at
+		FROM achievements_awarded
+		WHERE user_id = ?
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Achievement
+	for rows.Next() {
+		a := &Achievement{}
+		var awardedAt string
+		if err := rows.Scan(&a.ID, &a.Title, &a.PlayerID, &awardedAt); err != nil {
+			return nil, err
+		}
+		if t, err := time.Parse(time.RFC3339, awardedAt); err == nil {
+			a.AwardedAt = t
+		}
+		result = append(result, a)
+	}
+	return result, rows.Err()
+}
+
+// GameSessionClient — типизированные операции над таблицей games
+type GameSessionClient struct {
+	db *sql.DB
+}
+
+// GameSessionListOptions описывает фильтрацию и пагинацию для GameSessionClient.List
+type GameSessionListOptions struct {
+	Page         int
+	ItemsPerPage int
+	Finished     *bool
+}
+
+// List возвращает страницу игровых сессий вместе с общим количеством строк, удовлетворяющих
+// фильтру (для GameSessionList.totalItems в openapi.yaml). player_id используется как ID сессии,
+// так как таблица games хранит не более одной активной партии на игрока
+func (gc *GameSessionClient) List(opts GameSessionListOptions) ([]*GameSession, int, error) {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.ItemsPerPage < 1 {
+		opts.ItemsPerPage = 20
+	}
+
+	where := ""
+	args := []interface{}{}
+	if opts.Finished != nil {
+		where = "WHERE finished = ?"
+		args = append(args, *opts.Finished)
+	}
+
+	var total int
+	if err := gc.db.QueryRow(`SELECT COUNT(*) FROM games `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, opts.ItemsPerPage, (opts.Page-1)*opts.ItemsPerPage)
+	rows, err := gc.db.Query(`
+		SELECT player_id, difficulty, time_limit, started, finished, start_time, end_time
+		FROM games
+		`+where+`
+		ORDER BY player_id
+		LIMIT ? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*GameSession
+	for rows.Next() {
+		g := &GameSession{}
+		var startTime string
+		var endTime sql.NullString
+		if err := rows.Scan(&g.ID, &g.Difficulty, &g.TimeLimit, &g.Started, &g.Finished, &startTime, &endTime); err != nil {
+			return nil, 0, err
+		}
+		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
+			g.StartTime = t
+		}
+		if endTime.Valid {
+			if t, err := time.Parse(time.RFC3339, endTime.String); err == nil {
+				g.EndTime = &t
+			}
+		}
+		result = append(result, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, total, nil
+}
@@ -1,18 +1,30 @@
 package main
 
 import (
+	"bytes"
+	cryptorand "crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
-	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/gorilla/mux"
+	"github.com/fasthttp/router"
+	"github.com/fasthttp/websocket"
+	"github.com/golang-jwt/jwt/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/bcrypt"
+
+	"casino/ent"
+	"casino/ogent"
 )
 
 // Card представляет карту в игре
@@ -32,6 +44,8 @@ type Player struct {
 	Achievements []string  `json:"achievements"`
 	GamesPlayed  int       `json:"games_played"`
 	CreatedAt    time.Time `json:"created_at"`
+	Rating       int       `json:"rating"`
+	RatedGames   int       `json:"rated_games"`
 }
 
 // GameState представляет состояние игры
@@ -53,9 +67,54 @@ type LeaderboardEntry struct {
 	PlayerName string `json:"player_name"`
 	Score      int    `json:"score"`
 	GamesWon   int    `json:"games_won"`
+	Rating     int    `json:"rating"`
+}
+
+// Tournament представляет турнир с одиночным выбыванием
+type Tournament struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"` // pending, active, finished
+	CreatedAt time.Time `json:"created_at"`
+	Rounds    int       `json:"rounds"` // общее число раундов в сетке
+	Current   int       `json:"current_round"`
+}
+
+// TournamentParticipant представляет игрока, зарегистрированного в турнире
+type TournamentParticipant struct {
+	TournamentID int  `json:"tournament_id"`
+	PlayerID     int  `json:"player_id"`
+	Seed         int  `json:"seed"`
+	Eliminated   bool `json:"eliminated"`
+	IsBye        bool `json:"is_bye"` // заполняющий "призрак" для выравнивания сетки
+}
+
+// TournamentRound представляет один матч бракета в рамках раунда
+type TournamentRound struct {
+	TournamentID int    `json:"tournament_id"`
+	Round        int    `json:"round"`
+	Match        int    `json:"match"` // номер матча внутри раунда
+	PlayerAID    int    `json:"player_a_id"`
+	PlayerBID    int    `json:"player_b_id"` // 0, если бай (player A проходит автоматически)
+	ScoreA       int    `json:"score_a"`
+	ScoreB       int    `json:"score_b"`
+	WinnerID     int    `json:"winner_id"`
+	Reported     bool   `json:"reported"`
+	Status       string `json:"status"` // pending, reported, bye
+}
+
+// RatingHistoryEntry представляет одно изменение рейтинга игрока по итогам турнирного матча
+type RatingHistoryEntry struct {
+	ID           int       `json:"id"`
+	PlayerID     int       `json:"player_id"`
+	TournamentID int       `json:"tournament_id"`
+	RatingBefore int       `json:"rating_before"`
+	RatingAfter  int       `json:"rating_after"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 var (
+	db           *sql.DB
 	players      []Player
 	games        map[int]GameState
 	leaderboard  []LeaderboardEntry
@@ -68,56 +127,150 @@ var (
 		"Мастер памяти",
 	}
 	mutex sync.Mutex
+
+	tournaments            []Tournament
+	tournamentParticipants []TournamentParticipant
+	tournamentRounds       []TournamentRound
+	nextTournamentID       = 1
+	tournamentMutex        sync.Mutex
+)
+
+const (
+	unratedEloK       = 32
+	ratedEloK         = 16
+	unratedGamesLimit = 30
+)
+
+// jwtSecret подписывает access- и отдельно не подписываемые refresh-токены.
+// accessTokenTTL — время жизни access-токена; refreshTokenTTL вынесен в переменную,
+// чтобы его можно было переопределить (конфигурацией или в тестах).
+var (
+	jwtSecret       = []byte("casino-dev-secret-change-in-prod")
+	refreshTokenTTL = 7 * 24 * time.Hour
 )
 
+const accessTokenTTL = 15 * time.Minute
+
+// authClaims переносит идентификатор игрока внутри JWT access-токена
+type authClaims struct {
+	PlayerID int `json:"player_id"`
+	jwt.RegisteredClaims
+}
+
+// pooledEncoder переиспользует bytes.Buffer и json.Encoder, чтобы не аллоцировать их
+// заново на каждый ответ под нагрузкой
+type pooledEncoder struct {
+	buf *bytes.Buffer
+	enc *json.Encoder
+}
+
+var encoderPool = sync.Pool{
+	New: func() interface{} {
+		buf := new(bytes.Buffer)
+		return &pooledEncoder{buf: buf, enc: json.NewEncoder(buf)}
+	},
+}
+
+// writeJSON кодирует v через пуловый энкодер и пишет результат в ответ fasthttp
+func writeJSON(ctx *fasthttp.RequestCtx, v interface{}) {
+	pe := encoderPool.Get().(*pooledEncoder)
+	pe.buf.Reset()
+	defer encoderPool.Put(pe)
+
+	if err := pe.enc.Encode(v); err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка кодирования JSON: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.Write(pe.buf.Bytes())
+}
+
+// wsUpgrader апгрейдит HTTP-соединение до WebSocket для стриминга состояния игры
+var wsUpgrader = websocket.FastHTTPUpgrader{
+	CheckOrigin: func(ctx *fasthttp.RequestCtx) bool { return true },
+}
+
 func main() {
 	// Создание или открытие базы данных
-	db, err := newDB()
+	var err error
+	db, err = newDB()
 	if err != nil {
 		log.Fatalf("Ошибка при подключении к базе данных: %v", err)
 	}
 	defer db.Close()
-	// Инициализация данных
-	games = make(map[int]GameState)
-	players = append(players, Player{
-		ID:        1,
-		Name:      "Игрок 1",
-		Score:     0,
-		Coins:     1000,
-		CreatedAt: time.Now(),
-	})
 
 	//создание таблицы с игроками
 	createTables(db)
 
+	// Восстанавливаем активные игры, не завершенные до перезапуска
+	games = loadActiveGames(db)
+
+	// Восстанавливаем турниры, засев и сыгранные раунды
+	tournaments, tournamentParticipants, tournamentRounds, nextTournamentID = loadTournaments(db)
+
+	// Восстанавливаем игроков (очки, монеты, рейтинг, достижения) после перезапуска
+	players = loadPlayers(db)
+
 	// Инициализация таблицы лидеров
 	updateLeaderboard()
 
-	r := mux.NewRouter()
-	registerRoutes(r)
+	// Завершает игры, чье время истекло, даже если клиент ни разу не обратился к /flip
+	go expireStaleGames()
+
+	r := newRouter()
 
 	log.Println("Memory Casino Server is running at http://localhost:8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	log.Fatal(fasthttp.ListenAndServe(":8080", r.Handler))
 }
 
-func registerRoutes(r *mux.Router) {
+// newRouter собирает маршрутизатор fasthttp со всеми обработчиками приложения
+func newRouter() *router.Router {
+	r := router.New()
+
 	// Игроки
-	r.HandleFunc("/players", getPlayersHandler).Methods("GET")
-	r.HandleFunc("/players", createPlayerHandler).Methods("POST")
-	r.HandleFunc("/players/{id}", getPlayerHandler).Methods("GET")
-	r.HandleFunc("/players/{id}/achievements", getPlayerAchievementsHandler).Methods("GET")
+	r.GET("/players", getPlayersHandler)
+	r.POST("/players", createPlayerHandler)
+	r.GET("/players/{id}", getPlayerHandler)
+	r.GET("/players/{id}/achievements", getPlayerAchievementsHandler)
+	r.GET("/players/{id}/rating-history", getPlayerRatingHistoryHandler)
+
+	// Документированный ogent-слой поверх ent.Client (см. ent/openapi.yaml) — вынесен под /v2,
+	// чтобы не конфликтовать с путями основного игрового API выше
+	r.GET("/v2/players", ogentHandler(ogent.ListPlayer))
+	r.POST("/v2/players", ogentHandler(ogent.CreatePlayer))
+	r.GET("/v2/players/{id}", ogentHandler(ogent.ReadPlayer))
+	r.GET("/v2/game-sessions", ogentHandler(ogent.ListGameSession))
+
+	// Аутентификация
+	r.POST("/auth/register", registerHandler)
+	r.POST("/auth/login", loginHandler)
+	r.POST("/auth/refresh", refreshHandler)
 
 	// Игра
-	r.HandleFunc("/game/{player_id}/start", startGameHandler).Methods("POST")
-	r.HandleFunc("/game/{player_id}/flip/{card_id}", flipCardHandler).Methods("POST")
-	r.HandleFunc("/game/{player_id}/state", getGameStateHandler).Methods("GET")
-	r.HandleFunc("/game/{player_id}/end", endGameHandler).Methods("POST")
+	r.POST("/game/{player_id}/start", authMiddleware(startGameHandler))
+	r.POST("/game/{player_id}/flip/{card_id}", authMiddleware(flipCardHandler))
+	r.GET("/game/{player_id}/state", authMiddleware(getGameStateHandler))
+	r.POST("/game/{player_id}/end", authMiddleware(endGameHandler))
+
+	// Живой канал состояния игры
+	r.GET("/ws/game/{player_id}", authMiddleware(gameWSHandler))
 
 	// Таблица лидеров
-	r.HandleFunc("/leaderboard", getLeaderboardHandler).Methods("GET")
+	r.GET("/leaderboard", getLeaderboardHandler)
 
 	// Статистика
-	r.HandleFunc("/stats", getGameStatsHandler).Methods("GET")
+	r.GET("/stats", getGameStatsHandler)
+
+	// Турниры
+	r.GET("/tournaments", getTournamentsHandler)
+	r.POST("/tournaments", createTournamentHandler)
+	r.POST("/tournaments/{id}/join", requireAuth(joinTournamentHandler))
+	r.POST("/tournaments/{id}/start", startTournamentHandler)
+	r.GET("/tournaments/{id}/rounds", getTournamentRoundsHandler)
+	r.POST("/tournaments/{id}/report", requireAuth(reportTournamentResultHandler))
+
+	return r
 }
 
 func newDB() (*sql.DB, error) {
@@ -136,6 +289,23 @@ func newDB() (*sql.DB, error) {
 	return db, nil
 }
 
+// ogentHandler открывает БД и оборачивает обработчик ogent (принимающий *ent.Client) в
+// fasthttp.RequestHandler, как это делают остальные обработчики players/* через newDB
+func ogentHandler(handler func(*ent.Client, *fasthttp.RequestCtx)) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		db, err := newDB()
+		if err != nil {
+			ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
+			return
+		}
+		defer db.Close()
+
+		handler(ent.NewClient(db), ctx)
+	}
+}
+
+// createTables создает таблицы SQLite — схема здесь должна оставаться в синхроне
+// с сущностями в ent/client.go, пока в проекте нет настоящего ent/migrate.
 func createTables(db *sql.DB) {
 	players_bd := `
 	CREATE TABLE IF NOT EXISTS players (
@@ -143,36 +313,142 @@ func createTables(db *sql.DB) {
 	name text NOT NULL,
 	score INTEGER,
 	coins INTEGER,
-	GamesPlayed INTEGER);`
+	GamesPlayed INTEGER,
+	CreatedAt TEXT,
+	rating INTEGER,
+	rated_games INTEGER);`
 
-	achievements_bd := `
-	CREATE TABLE IF NOT EXISTS achievements(
-	title TEXT CHECK(status IN ("Первая игра",
-		"5 игр сыграно",
-		"10 игр сыграно",
-		"Быстрая победа",
-		"Сложный уровень",
-		"Мастер памяти")),
+	_, err := db.Exec(players_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы users: %v", err)
+	}
+
+	tournaments_bd := `
+	CREATE TABLE IF NOT EXISTS tournaments (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	status TEXT NOT NULL,
+	rounds INTEGER,
+	current_round INTEGER);`
+
+	tournament_participants_bd := `
+	CREATE TABLE IF NOT EXISTS tournament_participants (
+	tournament_id INTEGER,
+	player_id INTEGER,
+	seed INTEGER,
+	eliminated INTEGER,
+	is_bye INTEGER,
+	FOREIGN KEY(tournament_id) REFERENCES tournaments (id),
+	FOREIGN KEY(player_id) REFERENCES players (id));`
+
+	tournament_rounds_bd := `
+	CREATE TABLE IF NOT EXISTS tournament_rounds (
+	tournament_id INTEGER,
+	round INTEGER,
+	match INTEGER,
+	player_a_id INTEGER,
+	player_b_id INTEGER,
+	score_a INTEGER,
+	score_b INTEGER,
+	winner_id INTEGER,
+	status TEXT,
+	UNIQUE(tournament_id, round, match),
+	FOREIGN KEY(tournament_id) REFERENCES tournaments (id));`
+
+	rating_history_bd := `
+	CREATE TABLE IF NOT EXISTS rating_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	player_id INTEGER,
+	tournament_id INTEGER,
+	rating_before INTEGER,
+	rating_after INTEGER,
+	created_at TEXT,
+	FOREIGN KEY(player_id) REFERENCES players (id));`
+
+	_, err = db.Exec(tournaments_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы tournaments: %v", err)
+	}
+
+	_, err = db.Exec(tournament_participants_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы tournament_participants: %v", err)
+	}
+
+	_, err = db.Exec(tournament_rounds_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы tournament_rounds: %v", err)
+	}
+
+	_, err = db.Exec(rating_history_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы rating_history: %v", err)
+	}
+
+	games_bd := `
+	CREATE TABLE IF NOT EXISTS games (
+	player_id INTEGER PRIMARY KEY,
+	difficulty TEXT,
+	time_limit INTEGER,
+	started INTEGER,
+	finished INTEGER,
+	cards TEXT,
+	last_flipped TEXT,
+	start_time TEXT,
+	end_time TEXT,
+	FOREIGN KEY(player_id) REFERENCES players (id));`
+
+	achievements_awarded_bd := `
+	CREATE TABLE IF NOT EXISTS achievements_awarded (
 	user_id INTEGER,
+	title TEXT,
+	awarded_at TEXT,
+	UNIQUE(user_id, title),
 	FOREIGN KEY(user_id) REFERENCES players (id));`
 
-	_, err := db.Exec(players_bd)
+	_, err = db.Exec(games_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы games: %v", err)
+	}
+
+	_, err = db.Exec(achievements_awarded_bd)
+	if err != nil {
+		log.Fatalf("Ошибка создания таблицы achievements_awarded: %v", err)
+	}
+
+	users_bd := `
+	CREATE TABLE IF NOT EXISTS users (
+	player_id INTEGER PRIMARY KEY,
+	password_hash TEXT NOT NULL,
+	FOREIGN KEY(player_id) REFERENCES players (id));`
+
+	refresh_tokens_bd := `
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+	token TEXT PRIMARY KEY,
+	player_id INTEGER NOT NULL,
+	expires_at TEXT NOT NULL,
+	FOREIGN KEY(player_id) REFERENCES players (id));`
+
+	_, err = db.Exec(users_bd)
 	if err != nil {
 		log.Fatalf("Ошибка создания таблицы users: %v", err)
 	}
 
-	_, err = db.Exec(achievements_bd)
+	_, err = db.Exec(refresh_tokens_bd)
 	if err != nil {
-		log.Fatalf("Ошибка создания таблицы tasks: %v", err)
+		log.Fatalf("Ошибка создания таблицы refresh_tokens: %v", err)
 	}
 }
 
+// endGameForPlayer завершает игру и обновляет статистику игрока. Вызывающий обязан
+// уже держать mutex (сама функция и checkAchievements, которую она вызывает, не лочат).
 func endGameForPlayer(playerID int, won bool) {
 	if game, exists := games[playerID]; exists {
 		now := time.Now()
 		game.EndTime = &now
 		game.Finished = true
 		games[playerID] = game
+		deleteGameState(playerID)
 
 		// Обновляем статистику игрока
 		for i := range players {
@@ -190,30 +466,34 @@ func endGameForPlayer(playerID int, won bool) {
 
 				// Проверяем другие достижения
 				checkAchievements(&players[i], "games_played")
+				persistPlayer(players[i])
 				break
 			}
 		}
 	}
 }
 
+// checkAchievements начисляет достижения по указанному триггеру. Вызывающий обязан
+// уже держать mutex — достижения проверяются только из endGameForPlayer, вызываемого
+// из обработчиков, которые mutex уже захватили, и повторный Lock тут привел бы к дедлоку.
 func checkAchievements(player *Player, trigger string) {
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	switch trigger {
 	case "games_played":
 		if player.GamesPlayed >= 5 && !contains(player.Achievements, "5 игр сыграно") {
 			player.Achievements = append(player.Achievements, "5 игр сыграно")
 			player.Coins += 200
+			recordAchievementAward(player.ID, "5 игр сыграно")
 		}
 		if player.GamesPlayed >= 10 && !contains(player.Achievements, "10 игр сыграно") {
 			player.Achievements = append(player.Achievements, "10 игр сыграно")
 			player.Coins += 500
+			recordAchievementAward(player.ID, "10 игр сыграно")
 		}
 	case "game_won":
 		if !contains(player.Achievements, "Первая игра") {
 			player.Achievements = append(player.Achievements, "Первая игра")
 			player.Coins += 100
+			recordAchievementAward(player.ID, "Первая игра")
 		}
 	}
 }
@@ -227,70 +507,753 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func createPlayerHandler(w http.ResponseWriter, r *http.Request) {
+// Персистентность игр и достижений
+
+// withTx выполняет fn в транзакции и коммитит ее, если fn не вернула ошибку
+func withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// persistGameState сохраняет (или обновляет) строку игры целиком в одной транзакции,
+// включая колоду карт и последние перевернутые карты в виде JSON
+func persistGameState(game GameState) {
+	if db == nil {
+		return
+	}
+
+	cardsJSON, err := json.Marshal(game.Cards)
+	if err != nil {
+		log.Printf("Ошибка сериализации карт: %v", err)
+		return
+	}
+
+	lastFlippedJSON, err := json.Marshal(game.LastFlipped)
+	if err != nil {
+		log.Printf("Ошибка сериализации last_flipped: %v", err)
+		return
+	}
+
+	var endTime interface{}
+	if game.EndTime != nil {
+		endTime = game.EndTime.Format(time.RFC3339)
+	}
+
+	err = withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO games (player_id, difficulty, time_limit, started, finished, cards, last_flipped, start_time, end_time)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(player_id) DO UPDATE SET
+				difficulty=excluded.difficulty,
+				time_limit=excluded.time_limit,
+				started=excluded.started,
+				finished=excluded.finished,
+				cards=excluded.cards,
+				last_flipped=excluded.last_flipped,
+				start_time=excluded.start_time,
+				end_time=excluded.end_time
+		`, game.PlayerID, game.Difficulty, game.TimeLimit, game.Started, game.Finished,
+			string(cardsJSON), string(lastFlippedJSON), game.StartTime.Format(time.RFC3339), endTime)
+		return err
+	})
+	if err != nil {
+		log.Printf("Ошибка сохранения состояния игры: %v", err)
+	}
+}
+
+// deleteGameState убирает строку игры из таблицы, когда игра завершена и
+// больше не должна восстанавливаться после перезапуска
+func deleteGameState(playerID int) {
+	if db == nil {
+		return
+	}
+
+	if _, err := db.Exec(`DELETE FROM games WHERE player_id = ?`, playerID); err != nil {
+		log.Printf("Ошибка удаления состояния игры: %v", err)
+	}
+}
+
+// persistPlayer сохраняет изменяемые игровые поля игрока (очки, монеты, количество игр,
+// рейтинг) в таблицу players — вызывается каждым обработчиком, который мутирует
+// соответствующие поля в players, чтобы перезапуск сервера не откатывал прогресс игрока
+func persistPlayer(player Player) {
+	if db == nil {
+		return
+	}
+
+	err := withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE players
+			SET score = ?, coins = ?, GamesPlayed = ?, rating = ?, rated_games = ?
+			WHERE id = ?
+		`, player.Score, player.Coins, player.GamesPlayed, player.Rating, player.RatedGames, player.ID)
+		return err
+	})
+	if err != nil {
+		log.Printf("Ошибка сохранения игрока: %v", err)
+	}
+}
+
+// recordAchievementAward фиксирует выдачу достижения; уникальный индекс (user_id, title)
+// не даст начислить одно и то же достижение дважды
+func recordAchievementAward(playerID int, title string) {
+	if db == nil {
+		return
+	}
+
+	if err := ent.NewClient(db).Achievement().Create(playerID, title); err != nil {
+		log.Printf("Ошибка записи достижения: %v", err)
+	}
+}
+
+// loadActiveGames восстанавливает незавершенные игры после перезапуска сервера
+func loadActiveGames(db *sql.DB) map[int]GameState {
+	loaded := make(map[int]GameState)
+
+	rows, err := db.Query(`
+		SELECT player_id, difficulty, time_limit, started, finished, cards, last_flipped, start_time, end_time
+		FROM games
+		WHERE finished = 0
+	`)
+	if err != nil {
+		log.Printf("Ошибка загрузки активных игр: %v", err)
+		return loaded
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			game                       GameState
+			cardsJSON, lastFlippedJSON string
+			startTimeStr               string
+			endTimeStr                 sql.NullString
+		)
+
+		if err := rows.Scan(&game.PlayerID, &game.Difficulty, &game.TimeLimit, &game.Started,
+			&game.Finished, &cardsJSON, &lastFlippedJSON, &startTimeStr, &endTimeStr); err != nil {
+			log.Printf("Ошибка чтения сохраненной игры: %v", err)
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(cardsJSON), &game.Cards); err != nil {
+			log.Printf("Ошибка десериализации карт: %v", err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(lastFlippedJSON), &game.LastFlipped); err != nil {
+			log.Printf("Ошибка десериализации last_flipped: %v", err)
+			continue
+		}
+
+		if startTime, err := time.Parse(time.RFC3339, startTimeStr); err == nil {
+			game.StartTime = startTime
+		}
+		if endTimeStr.Valid {
+			if endTime, err := time.Parse(time.RFC3339, endTimeStr.String); err == nil {
+				game.EndTime = &endTime
+			}
+		}
+
+		loaded[game.PlayerID] = game
+	}
+
+	return loaded
+}
+
+// loadPlayers восстанавливает игроков (очки, монеты, рейтинг и начисленные достижения)
+// из таблицы players после перезапуска сервера, вместо того чтобы начинать с пустого слайса
+func loadPlayers(db *sql.DB) []Player {
+	var loaded []Player
+
+	rows, err := db.Query(`
+		SELECT id, name, score, coins, GamesPlayed, CreatedAt, rating, rated_games
+		FROM players
+	`)
+	if err != nil {
+		log.Printf("Ошибка загрузки игроков: %v", err)
+		return loaded
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			player       Player
+			createdAtStr string
+			rating       sql.NullInt64
+			ratedGames   sql.NullInt64
+		)
+
+		if err := rows.Scan(&player.ID, &player.Name, &player.Score, &player.Coins,
+			&player.GamesPlayed, &createdAtStr, &rating, &ratedGames); err != nil {
+			log.Printf("Ошибка чтения игрока: %v", err)
+			continue
+		}
+
+		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
+			player.CreatedAt = createdAt
+		}
+		if rating.Valid {
+			player.Rating = int(rating.Int64)
+		} else {
+			player.Rating = 1500
+		}
+		if ratedGames.Valid {
+			player.RatedGames = int(ratedGames.Int64)
+		}
+
+		loaded = append(loaded, player)
+	}
+
+	achievementRows, err := db.Query(`SELECT user_id, title FROM achievements_awarded`)
+	if err != nil {
+		log.Printf("Ошибка загрузки достижений: %v", err)
+		return loaded
+	}
+	defer achievementRows.Close()
+
+	for achievementRows.Next() {
+		var playerID int
+		var title string
+		if err := achievementRows.Scan(&playerID, &title); err != nil {
+			log.Printf("Ошибка чтения достижения: %v", err)
+			continue
+		}
+		for i := range loaded {
+			if loaded[i].ID == playerID {
+				loaded[i].Achievements = append(loaded[i].Achievements, title)
+				break
+			}
+		}
+	}
+
+	return loaded
+}
+
+// expireStaleGames раз в секунду завершает игры, чье время истекло, даже если клиент
+// ни разу не обратился к /flip
+func expireStaleGames() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mutex.Lock()
+		var expired []int
+		for playerID, game := range games {
+			if game.Started && !game.Finished && time.Since(game.StartTime).Seconds() > float64(game.TimeLimit) {
+				expired = append(expired, playerID)
+			}
+		}
+		for _, playerID := range expired {
+			endGameForPlayer(playerID, false)
+		}
+		mutex.Unlock()
+	}
+}
+
+// Персистентность турниров
+
+// persistTournament сохраняет (или обновляет) турнир целиком в таблице tournaments
+func persistTournament(t Tournament) {
+	if db == nil {
+		return
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tournaments (id, name, status, rounds, current_round)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name=excluded.name,
+			status=excluded.status,
+			rounds=excluded.rounds,
+			current_round=excluded.current_round
+	`, t.ID, t.Name, t.Status, t.Rounds, t.Current)
+	if err != nil {
+		log.Printf("Ошибка сохранения турнира: %v", err)
+	}
+}
+
+// persistTournamentParticipant сохраняет засев игрока (или бая) в турнир
+func persistTournamentParticipant(p TournamentParticipant) {
+	if db == nil {
+		return
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tournament_participants (tournament_id, player_id, seed, eliminated, is_bye)
+		VALUES (?, ?, ?, ?, ?)
+	`, p.TournamentID, p.PlayerID, p.Seed, p.Eliminated, p.IsBye)
+	if err != nil {
+		log.Printf("Ошибка сохранения участника турнира: %v", err)
+	}
+}
+
+// persistTournamentRound сохраняет (или обновляет по мере отыгрывания) один матч бракета
+func persistTournamentRound(r TournamentRound) {
+	if db == nil {
+		return
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tournament_rounds (tournament_id, round, match, player_a_id, player_b_id, score_a, score_b, winner_id, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(tournament_id, round, match) DO UPDATE SET
+			score_a=excluded.score_a,
+			score_b=excluded.score_b,
+			winner_id=excluded.winner_id,
+			status=excluded.status
+	`, r.TournamentID, r.Round, r.Match, r.PlayerAID, r.PlayerBID, r.ScoreA, r.ScoreB, r.WinnerID, r.Status)
+	if err != nil {
+		log.Printf("Ошибка сохранения матча турнира: %v", err)
+	}
+}
+
+// recordRatingHistory фиксирует изменение рейтинга игрока по итогам турнирного матча
+func recordRatingHistory(playerID, tournamentID, ratingBefore, ratingAfter int) {
+	if db == nil {
+		return
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO rating_history (player_id, tournament_id, rating_before, rating_after, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, playerID, tournamentID, ratingBefore, ratingAfter, time.Now().Format(time.RFC3339))
+	if err != nil {
+		log.Printf("Ошибка записи истории рейтинга: %v", err)
+	}
+}
+
+// loadTournaments восстанавливает турниры, засев и сыгранные раунды после перезапуска сервера
+func loadTournaments(db *sql.DB) ([]Tournament, []TournamentParticipant, []TournamentRound, int) {
+	var loadedTournaments []Tournament
+	nextID := 1
+
+	rows, err := db.Query(`SELECT id, name, status, rounds, current_round FROM tournaments`)
+	if err != nil {
+		log.Printf("Ошибка загрузки турниров: %v", err)
+		return nil, nil, nil, nextID
+	}
+	for rows.Next() {
+		var t Tournament
+		if err := rows.Scan(&t.ID, &t.Name, &t.Status, &t.Rounds, &t.Current); err != nil {
+			log.Printf("Ошибка чтения турнира: %v", err)
+			continue
+		}
+		loadedTournaments = append(loadedTournaments, t)
+		if t.ID >= nextID {
+			nextID = t.ID + 1
+		}
+	}
+	rows.Close()
+
+	var loadedParticipants []TournamentParticipant
+	rows, err = db.Query(`SELECT tournament_id, player_id, seed, eliminated, is_bye FROM tournament_participants`)
+	if err != nil {
+		log.Printf("Ошибка загрузки участников турниров: %v", err)
+		return loadedTournaments, nil, nil, nextID
+	}
+	for rows.Next() {
+		var p TournamentParticipant
+		if err := rows.Scan(&p.TournamentID, &p.PlayerID, &p.Seed, &p.Eliminated, &p.IsBye); err != nil {
+			log.Printf("Ошибка чтения участника турнира: %v", err)
+			continue
+		}
+		loadedParticipants = append(loadedParticipants, p)
+	}
+	rows.Close()
+
+	var loadedRounds []TournamentRound
+	rows, err = db.Query(`
+		SELECT tournament_id, round, match, player_a_id, player_b_id, score_a, score_b, winner_id, status
+		FROM tournament_rounds
+		ORDER BY tournament_id, round, match
+	`)
+	if err != nil {
+		log.Printf("Ошибка загрузки матчей турниров: %v", err)
+		return loadedTournaments, loadedParticipants, nil, nextID
+	}
+	for rows.Next() {
+		var r TournamentRound
+		if err := rows.Scan(&r.TournamentID, &r.Round, &r.Match, &r.PlayerAID, &r.PlayerBID,
+			&r.ScoreA, &r.ScoreB, &r.WinnerID, &r.Status); err != nil {
+			log.Printf("Ошибка чтения матча турнира: %v", err)
+			continue
+		}
+		r.Reported = r.Status == "reported" || r.Status == "bye"
+		loadedRounds = append(loadedRounds, r)
+	}
+	rows.Close()
+
+	return loadedTournaments, loadedParticipants, loadedRounds, nextID
+}
+
+// Аутентификация
+
+// generateAccessToken подписывает короткоживущий JWT с идентификатором игрока
+func generateAccessToken(playerID int) (string, error) {
+	claims := authClaims{
+		PlayerID: playerID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// issueRefreshToken генерирует случайный refresh-токен и сохраняет его в БД с TTL
+// refreshTokenTTL, чтобы обновлять access-токен без повторного ввода пароля
+func issueRefreshToken(db *sql.DB, playerID int) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := cryptorand.Read(raw); err != nil {
+		return "", fmt.Errorf("ошибка генерации refresh-токена: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	_, err := db.Exec(
+		`INSERT INTO refresh_tokens (token, player_id, expires_at) VALUES (?, ?, ?)`,
+		token, playerID, time.Now().Add(refreshTokenTTL).Format(time.RFC3339),
+	)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сохранения refresh-токена: %w", err)
+	}
+
+	return token, nil
+}
+
+// requireAuth проверяет Bearer-JWT (из заголовка Authorization либо, для WebSocket-клиентов,
+// не умеющих выставлять заголовки, из query-параметра token) и прокидывает ID игрока из
+// токена дальше через ctx, чтобы обработчик сверил его с тем, над чьими данными он работает
+func requireAuth(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		tokenStr, ok := strings.CutPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
+		if !ok || tokenStr == "" {
+			tokenStr = string(ctx.QueryArgs().Peek("token"))
+		}
+		if tokenStr == "" {
+			ctx.Error("Требуется авторизация", fasthttp.StatusUnauthorized)
+			return
+		}
+
+		claims := &authClaims{}
+		token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		})
+		if err != nil || !token.Valid {
+			ctx.Error("Недействительный или просроченный токен", fasthttp.StatusUnauthorized)
+			return
+		}
+
+		ctx.SetUserValue("auth_player_id", claims.PlayerID)
+		next(ctx)
+	}
+}
+
+// authenticatedPlayerID возвращает ID игрока, извлеченный requireAuth из токена текущего запроса
+func authenticatedPlayerID(ctx *fasthttp.RequestCtx) (int, bool) {
+	id, ok := ctx.UserValue("auth_player_id").(int)
+	return id, ok
+}
+
+// authMiddleware — requireAuth плюс проверка, что {player_id} в пути совпадает с игроком
+// из токена — иначе запрос отклоняется, даже если токен валиден
+func authMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return requireAuth(func(ctx *fasthttp.RequestCtx) {
+		authPlayerID, _ := authenticatedPlayerID(ctx)
+		if playerIDStr, _ := ctx.UserValue("player_id").(string); playerIDStr != "" &&
+			strconv.Itoa(authPlayerID) != playerIDStr {
+			ctx.Error("Токен не принадлежит этому игроку", fasthttp.StatusForbidden)
+			return
+		}
+		next(ctx)
+	})
+}
+
+// registerHandler создает игрока вместе с записью пароля в users и сразу выдает
+// пару access/refresh токенов, чтобы не требовать отдельного логина после регистрации
+func registerHandler(ctx *fasthttp.RequestCtx) {
 	db, err := newDB()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка подключения к БД: %v", err),
-			http.StatusInternalServerError)
+		ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
 		return
 	}
 	defer db.Close()
 
-	var player Player
-	if err := json.NewDecoder(r.Body).Decode(&player); err != nil {
-		http.Error(w, "Некорректный JSON", http.StatusBadRequest)
+	var req struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || req.Password == "" {
+		ctx.Error("Имя и пароль обязательны", fasthttp.StatusBadRequest)
 		return
 	}
-	defer r.Body.Close()
 
-	// Устанавливаем значения по умолчанию
-	player.Coins = 1000
-	player.GamesPlayed = 0
-	player.Score = 0
-	var name string
-	fmt.Print("Введите имя: ")
-	fmt.Scan(&name)
-	player.Name = name
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка хеширования пароля: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	player := Player{
+		Name:      req.Name,
+		Coins:     1000,
+		Score:     0,
+		Rating:    1500,
+		CreatedAt: time.Now(),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
 
-	// Вставляем игрока в БД
-	query := `
-        INSERT INTO players (name, score, coins, GamesPlayed, CreatedAt) 
-        VALUES (?, ?, ?, ?, ?)
+	err = tx.QueryRow(`
+        INSERT INTO players (name, score, coins, GamesPlayed, CreatedAt, rating, rated_games)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
         RETURNING id
-    `
-	err = db.QueryRow(
-		query,
-		player.Name,
-		player.Score,
-		player.Coins,
-		player.GamesPlayed,
-	).Scan(&player.ID)
+    `, player.Name, player.Score, player.Coins, player.GamesPlayed, player.CreatedAt, player.Rating, player.RatedGames).Scan(&player.ID)
+	if err != nil {
+		tx.Rollback()
+		ctx.Error(fmt.Sprintf("Ошибка создания игрока: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
 
+	if _, err := tx.Exec(`INSERT INTO users (player_id, password_hash) VALUES (?, ?)`, player.ID, string(passwordHash)); err != nil {
+		tx.Rollback()
+		ctx.Error(fmt.Sprintf("Ошибка сохранения пароля: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	mutex.Lock()
+	players = append(players, player)
+	mutex.Unlock()
+
+	accessToken, err := generateAccessToken(player.ID)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка генерации токена: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	refreshToken, err := issueRefreshToken(db, player.ID)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка создания игрока: %v", err),
-			http.StatusInternalServerError)
+		ctx.Error(fmt.Sprintf("%v", err), fasthttp.StatusInternalServerError)
 		return
 	}
 
-	// Отправляем созданного игрока в ответе
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(player); err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка кодирования JSON: %v", err),
-			http.StatusInternalServerError)
+	writeJSON(ctx, map[string]interface{}{
+		"player":        player,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+}
+
+// loginHandler проверяет пароль по хешу из users и выдает новую пару токенов
+func loginHandler(ctx *fasthttp.RequestCtx) {
+	db, err := newDB()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	var req struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
 	}
+
+	var playerID int
+	var passwordHash string
+	err = db.QueryRow(`
+        SELECT p.id, u.password_hash
+        FROM players p
+        JOIN users u ON u.player_id = p.id
+        WHERE p.name = ?
+    `, req.Name).Scan(&playerID, &passwordHash)
+	if err == sql.ErrNoRows {
+		ctx.Error("Неверное имя или пароль", fasthttp.StatusUnauthorized)
+		return
+	} else if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		ctx.Error("Неверное имя или пароль", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	accessToken, err := generateAccessToken(playerID)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка генерации токена: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	refreshToken, err := issueRefreshToken(db, playerID)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("%v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(ctx, map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
 }
 
-func getPlayerAchievementsHandler(w http.ResponseWriter, r *http.Request) {
-	idStr := mux.Vars(r)["id"]
+// refreshHandler обменивает еще не истекший refresh-токен на новый access-токен
+func refreshHandler(ctx *fasthttp.RequestCtx) {
+	db, err := newDB()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+
+	var playerID int
+	var expiresAtStr string
+	err = db.QueryRow(`SELECT player_id, expires_at FROM refresh_tokens WHERE token = ?`, req.RefreshToken).
+		Scan(&playerID, &expiresAtStr)
+	if err == sql.ErrNoRows {
+		ctx.Error("Недействительный refresh-токен", fasthttp.StatusUnauthorized)
+		return
+	} else if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, expiresAtStr)
+	if err != nil || time.Now().After(expiresAt) {
+		db.Exec(`DELETE FROM refresh_tokens WHERE token = ?`, req.RefreshToken)
+		ctx.Error("Refresh-токен истек", fasthttp.StatusUnauthorized)
+		return
+	}
+
+	accessToken, err := generateAccessToken(playerID)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка генерации токена: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(ctx, map[string]interface{}{
+		"access_token": accessToken,
+	})
+}
+
+// createPlayerHandler создает игрока вместе со строкой в users, как и registerHandler, —
+// без пароля игрок не смог бы потом пройти /auth/login и authMiddleware блокировал бы ему
+// все игровые эндпоинты
+func createPlayerHandler(ctx *fasthttp.RequestCtx) {
+	db, err := newDB()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	var req struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+	if req.Name == "" || req.Password == "" {
+		ctx.Error("Имя и пароль обязательны", fasthttp.StatusBadRequest)
+		return
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка хеширования пароля: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	player := Player{
+		Name:      req.Name,
+		Coins:     1000,
+		Rating:    1500,
+		CreatedAt: time.Now(),
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO players (name, score, coins, GamesPlayed, CreatedAt, rating, rated_games)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, player.Name, player.Score, player.Coins, player.GamesPlayed, player.CreatedAt.Format(time.RFC3339), player.Rating, player.RatedGames).Scan(&player.ID)
+	if err != nil {
+		tx.Rollback()
+		ctx.Error(fmt.Sprintf("Ошибка создания игрока: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec(`INSERT INTO users (player_id, password_hash) VALUES (?, ?)`, player.ID, string(passwordHash)); err != nil {
+		tx.Rollback()
+		ctx.Error(fmt.Sprintf("Ошибка сохранения пароля: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка базы данных: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	mutex.Lock()
+	players = append(players, player)
+	mutex.Unlock()
+
+	// Отправляем созданного игрока в ответе
+	writeJSON(ctx, player)
+}
+
+func getPlayerAchievementsHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID", http.StatusBadRequest)
+		ctx.Error("Некорректный ID", fasthttp.StatusBadRequest)
 		return
 	}
 
+	mutex.Lock()
+	defer mutex.Unlock()
+
 	for _, player := range players {
 		if player.ID == id {
-			json.NewEncoder(w).Encode(map[string]interface{}{
+			writeJSON(ctx, map[string]interface{}{
 				"player_id":    player.ID,
 				"achievements": player.Achievements,
 			})
@@ -298,25 +1261,76 @@ func getPlayerAchievementsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	http.NotFound(w, r)
+	ctx.Error("Не найдено", fasthttp.StatusNotFound)
+}
+
+// getPlayerRatingHistoryHandler возвращает историю изменений рейтинга игрока по турнирным матчам
+func getPlayerRatingHistoryHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID", fasthttp.StatusBadRequest)
+		return
+	}
+
+	db, err := newDB()
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка подключения к БД: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, tournament_id, rating_before, rating_after, created_at
+		FROM rating_history
+		WHERE player_id = ?
+		ORDER BY id
+	`, id)
+	if err != nil {
+		ctx.Error(fmt.Sprintf("Ошибка выполнения запроса: %v", err), fasthttp.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	history := []RatingHistoryEntry{}
+	for rows.Next() {
+		var entry RatingHistoryEntry
+		var createdAtStr string
+		if err := rows.Scan(&entry.ID, &entry.TournamentID, &entry.RatingBefore, &entry.RatingAfter, &createdAtStr); err != nil {
+			ctx.Error(fmt.Sprintf("Ошибка сканирования истории рейтинга: %v", err), fasthttp.StatusInternalServerError)
+			return
+		}
+		entry.PlayerID = id
+		if createdAt, err := time.Parse(time.RFC3339, createdAtStr); err == nil {
+			entry.CreatedAt = createdAt
+		}
+		history = append(history, entry)
+	}
+
+	writeJSON(ctx, history)
 }
 
 // Обработчики игры (обновленные)
 
-func startGameHandler(w http.ResponseWriter, r *http.Request) {
-	playerIDStr := mux.Vars(r)["player_id"]
+func startGameHandler(ctx *fasthttp.RequestCtx) {
+	playerIDStr, _ := ctx.UserValue("player_id").(string)
 	playerID, err := strconv.Atoi(playerIDStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID игрока", http.StatusBadRequest)
+		ctx.Error("Некорректный ID игрока", fasthttp.StatusBadRequest)
 		return
 	}
 
 	// Получаем параметр сложности
-	difficulty := r.URL.Query().Get("difficulty")
+	difficulty := string(ctx.QueryArgs().Peek("difficulty"))
 	if difficulty == "" {
 		difficulty = "medium"
 	}
 
+	// players читается и мутируется конкурентно (регистрация добавляет в слайс),
+	// поэтому lock берем уже перед первым чтением, а не только перед записью
+	mutex.Lock()
+	defer mutex.Unlock()
+
 	// Проверяем, что игрок существует
 	var player *Player
 	for i, p := range players {
@@ -327,7 +1341,7 @@ func startGameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if player == nil {
-		http.Error(w, "Игрок не найден", http.StatusNotFound)
+		ctx.Error("Игрок не найден", fasthttp.StatusNotFound)
 		return
 	}
 
@@ -344,19 +1358,16 @@ func startGameHandler(w http.ResponseWriter, r *http.Request) {
 		cost = 80
 		timeLimit = 180 // 3 минуты
 	default:
-		http.Error(w, "Некорректный уровень сложности", http.StatusBadRequest)
+		ctx.Error("Некорректный уровень сложности", fasthttp.StatusBadRequest)
 		return
 	}
 
 	// Проверяем, что у игрока достаточно монет для игры
 	if player.Coins < cost {
-		http.Error(w, "Недостаточно монет для начала игры", http.StatusBadRequest)
+		ctx.Error("Недостаточно монет для начала игры", fasthttp.StatusBadRequest)
 		return
 	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-
 	// Снимаем ставку
 	player.Coins -= cost
 
@@ -372,28 +1383,32 @@ func startGameHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	games[playerID] = game
+	persistGameState(game)
 
 	// Проверяем достижение для сложного уровня
 	if difficulty == "hard" && !contains(player.Achievements, "Сложный уровень") {
 		player.Achievements = append(player.Achievements, "Сложный уровень")
 		player.Coins += 300
+		recordAchievementAward(player.ID, "Сложный уровень")
 	}
 
-	json.NewEncoder(w).Encode(game)
+	persistPlayer(*player)
+
+	writeJSON(ctx, game)
 }
 
-func flipCardHandler(w http.ResponseWriter, r *http.Request) {
-	playerIDStr := mux.Vars(r)["player_id"]
+func flipCardHandler(ctx *fasthttp.RequestCtx) {
+	playerIDStr, _ := ctx.UserValue("player_id").(string)
 	playerID, err := strconv.Atoi(playerIDStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID игрока", http.StatusBadRequest)
+		ctx.Error("Некорректный ID игрока", fasthttp.StatusBadRequest)
 		return
 	}
 
-	cardIDStr := mux.Vars(r)["card_id"]
+	cardIDStr, _ := ctx.UserValue("card_id").(string)
 	cardID, err := strconv.Atoi(cardIDStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID карты", http.StatusBadRequest)
+		ctx.Error("Некорректный ID карты", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -402,14 +1417,14 @@ func flipCardHandler(w http.ResponseWriter, r *http.Request) {
 
 	game, exists := games[playerID]
 	if !exists || !game.Started || game.Finished {
-		http.Error(w, "Игра не начата или уже завершена", http.StatusBadRequest)
+		ctx.Error("Игра не начата или уже завершена", fasthttp.StatusBadRequest)
 		return
 	}
 
 	// Проверяем, не истекло ли время
 	if time.Since(game.StartTime).Seconds() > float64(game.TimeLimit) {
 		endGameForPlayer(playerID, false)
-		http.Error(w, "Время игры истекло", http.StatusBadRequest)
+		ctx.Error("Время игры истекло", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -423,13 +1438,13 @@ func flipCardHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if card == nil {
-		http.Error(w, "Карта не найдена", http.StatusNotFound)
+		ctx.Error("Карта не найдена", fasthttp.StatusNotFound)
 		return
 	}
 
 	// Нельзя переворачивать уже совпавшие или уже перевернутые карты
 	if card.Matched || card.Flipped {
-		http.Error(w, "Карта уже перевернута или совпала", http.StatusBadRequest)
+		ctx.Error("Карта уже перевернута или совпала", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -476,8 +1491,10 @@ func flipCardHandler(w http.ResponseWriter, r *http.Request) {
 						if allMatched {
 							players[i].Achievements = append(players[i].Achievements, "Мастер памяти")
 							players[i].Coins += 1000
+							recordAchievementAward(players[i].ID, "Мастер памяти")
 						}
 					}
+					persistPlayer(players[i])
 					break
 				}
 			}
@@ -503,25 +1520,119 @@ func flipCardHandler(w http.ResponseWriter, r *http.Request) {
 				if players[i].ID == playerID && !contains(players[i].Achievements, "Быстрая победа") {
 					players[i].Achievements = append(players[i].Achievements, "Быстрая победа")
 					players[i].Coins += 500
+					recordAchievementAward(players[i].ID, "Быстрая победа")
+					persistPlayer(players[i])
 					break
 				}
 			}
 		}
 
 		endGameForPlayer(playerID, true)
+		// Подхватываем Finished/EndTime, выставленные endGameForPlayer,
+		// чтобы не затереть их ниже устаревшей локальной копией
+		game = games[playerID]
 	}
 
 	// Обновляем состояние игры
 	games[playerID] = game
+	if !game.Finished {
+		persistGameState(game)
+	}
+
+	writeJSON(ctx, game)
+}
+
+// gameWSHandler стримит изменения GameState игроку, заменяя поллинг /game/{player_id}/state
+func gameWSHandler(ctx *fasthttp.RequestCtx) {
+	playerIDStr, _ := ctx.UserValue("player_id").(string)
+	playerID, err := strconv.Atoi(playerIDStr)
+	if err != nil {
+		ctx.Error("Некорректный ID игрока", fasthttp.StatusBadRequest)
+		return
+	}
+
+	err = wsUpgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+		streamGameState(conn, playerID)
+	})
+	if err != nil {
+		log.Printf("Ошибка апгрейда WebSocket: %v", err)
+	}
+}
+
+// streamGameState раз в секунду отправляет клиенту перевороты карт, события совпадений,
+// оставшееся время и финальный пейлоад по окончании игры
+func streamGameState(conn *websocket.Conn, playerID int) {
+	defer conn.Close()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	lastFlipped, lastMatched := -1, -1
+	for range ticker.C {
+		mutex.Lock()
+		game, exists := games[playerID]
+		mutex.Unlock()
+
+		if !exists {
+			conn.WriteJSON(map[string]interface{}{"event": "no_game"})
+			return
+		}
+
+		flippedCount, matchedCount := 0, 0
+		for _, c := range game.Cards {
+			if c.Flipped {
+				flippedCount++
+			}
+			if c.Matched {
+				matchedCount++
+			}
+		}
+
+		event := "tick"
+		if flippedCount != lastFlipped || matchedCount != lastMatched {
+			event = "state_changed"
+		}
+		lastFlipped, lastMatched = flippedCount, matchedCount
+
+		timeLeft := game.TimeLimit - int(time.Since(game.StartTime).Seconds())
+		if timeLeft < 0 {
+			timeLeft = 0
+		}
+
+		if err := conn.WriteJSON(map[string]interface{}{
+			"event":         event,
+			"game":          game,
+			"time_left":     timeLeft,
+			"cards_flipped": flippedCount,
+			"cards_matched": matchedCount,
+		}); err != nil {
+			return
+		}
 
-	json.NewEncoder(w).Encode(game)
+		if game.Finished {
+			conn.WriteJSON(map[string]interface{}{"event": "game_over", "game": game})
+			return
+		}
+	}
 }
 
 // Обработчики таблицы лидеров и статистики
 
-func getLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+func getLeaderboardHandler(ctx *fasthttp.RequestCtx) {
 	updateLeaderboard()
-	json.NewEncoder(w).Encode(leaderboard)
+
+	// По умолчанию сортируем по очкам, но поддерживаем ?sort=rating
+	if string(ctx.QueryArgs().Peek("sort")) == "rating" {
+		byRating := make([]LeaderboardEntry, len(leaderboard))
+		copy(byRating, leaderboard)
+		sort.Slice(byRating, func(i, j int) bool {
+			return byRating[i].Rating > byRating[j].Rating
+		})
+		writeJSON(ctx, byRating)
+		return
+	}
+
+	writeJSON(ctx, leaderboard)
 }
 
 func updateLeaderboard() {
@@ -538,6 +1649,7 @@ func updateLeaderboard() {
 			PlayerName: player.Name,
 			Score:      player.Score,
 			GamesWon:   gamesWon,
+			Rating:     player.Rating,
 		})
 	}
 
@@ -549,7 +1661,7 @@ func updateLeaderboard() {
 	leaderboard = entries
 }
 
-func getGameStatsHandler(w http.ResponseWriter, r *http.Request) {
+func getGameStatsHandler(ctx *fasthttp.RequestCtx) {
 	stats := map[string]interface{}{
 		"total_players":  len(players),
 		"total_games":    countTotalGames(),
@@ -558,7 +1670,7 @@ func getGameStatsHandler(w http.ResponseWriter, r *http.Request) {
 		"recent_winners": getRecentWinners(5),
 	}
 
-	json.NewEncoder(w).Encode(stats)
+	writeJSON(ctx, stats)
 }
 
 func countTotalGames() int {
@@ -640,60 +1752,30 @@ func createShuffledDeck(difficulty string) []Card {
 	return cards
 }
 
-func getPlayersHandler(w http.ResponseWriter, r *http.Request) {
+func getPlayersHandler(ctx *fasthttp.RequestCtx) {
 	db, err := newDB()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка подключения к базе данных: %v", err),
-			http.StatusInternalServerError)
+		ctx.Error(fmt.Sprintf("Ошибка подключения к базе данных: %v", err), fasthttp.StatusInternalServerError)
 		return
 	}
 	defer db.Close()
 
-	query := `
-        SELECT id, name, score, coins, GamesPlayed 
-        FROM players
-        ORDER BY score DESC
-    `
-	rows, err := db.Query(query)
+	players, err := ent.NewClient(db).Player().All()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка выполнения запроса: %v", err),
-			http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var players []Player
-	for rows.Next() {
-		var p Player
-		err := rows.Scan(&p.ID, &p.Name, &p.Score, &p.Coins, &p.GamesPlayed)
-		if err != nil {
-			http.Error(w, fmt.Sprintf("Ошибка сканирования данных игрока: %v", err),
-				http.StatusInternalServerError)
-			return
-		}
-		players = append(players, p)
-	}
-
-	if err = rows.Err(); err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка при обработке результатов: %v", err),
-			http.StatusInternalServerError)
+		ctx.Error(fmt.Sprintf("Ошибка выполнения запроса: %v", err), fasthttp.StatusInternalServerError)
 		return
 	}
 
 	//ответ
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(players); err != nil {
-		http.Error(w, fmt.Sprintf("Ошибка кодирования JSON: %v", err),
-			http.StatusInternalServerError)
-	}
+	writeJSON(ctx, players)
 }
 
 // getPlayerHandler возвращает информацию об игроке по ID
-func getPlayerHandler(w http.ResponseWriter, r *http.Request) {
-	idStr := mux.Vars(r)["id"]
+func getPlayerHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID", http.StatusBadRequest)
+		ctx.Error("Некорректный ID", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -702,20 +1784,20 @@ func getPlayerHandler(w http.ResponseWriter, r *http.Request) {
 
 	for _, player := range players {
 		if player.ID == id {
-			json.NewEncoder(w).Encode(player)
+			writeJSON(ctx, player)
 			return
 		}
 	}
 
-	http.NotFound(w, r)
+	ctx.Error("Не найдено", fasthttp.StatusNotFound)
 }
 
 // getGameStateHandler возвращает текущее состояние игры для игрока
-func getGameStateHandler(w http.ResponseWriter, r *http.Request) {
-	playerIDStr := mux.Vars(r)["player_id"]
+func getGameStateHandler(ctx *fasthttp.RequestCtx) {
+	playerIDStr, _ := ctx.UserValue("player_id").(string)
 	playerID, err := strconv.Atoi(playerIDStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID игрока", http.StatusBadRequest)
+		ctx.Error("Некорректный ID игрока", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -724,7 +1806,7 @@ func getGameStateHandler(w http.ResponseWriter, r *http.Request) {
 
 	game, exists := games[playerID]
 	if !exists {
-		http.NotFound(w, r)
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
 		return
 	}
 
@@ -742,15 +1824,15 @@ func getGameStateHandler(w http.ResponseWriter, r *http.Request) {
 		TimeLeft:  timeLeft,
 	}
 
-	json.NewEncoder(w).Encode(response)
+	writeJSON(ctx, response)
 }
 
 // endGameHandler завершает игру досрочно
-func endGameHandler(w http.ResponseWriter, r *http.Request) {
-	playerIDStr := mux.Vars(r)["player_id"]
+func endGameHandler(ctx *fasthttp.RequestCtx) {
+	playerIDStr, _ := ctx.UserValue("player_id").(string)
 	playerID, err := strconv.Atoi(playerIDStr)
 	if err != nil {
-		http.Error(w, "Некорректный ID игрока", http.StatusBadRequest)
+		ctx.Error("Некорректный ID игрока", fasthttp.StatusBadRequest)
 		return
 	}
 
@@ -762,9 +1844,482 @@ func endGameHandler(w http.ResponseWriter, r *http.Request) {
 			// Завершаем игру с флагом "не выиграна"
 			endGameForPlayer(playerID, false)
 		}
-		w.WriteHeader(http.StatusNoContent)
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+		return
+	}
+
+	ctx.Error("Не найдено", fasthttp.StatusNotFound)
+}
+
+// Обработчики турниров
+
+// createTournamentHandler создает новый турнир в статусе "pending"
+func createTournamentHandler(ctx *fasthttp.RequestCtx) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+
+	if body.Name == "" {
+		ctx.Error("Укажите название турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	tournament := Tournament{
+		ID:        nextTournamentID,
+		Name:      body.Name,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	nextTournamentID++
+	tournaments = append(tournaments, tournament)
+	persistTournament(tournament)
+
+	writeJSON(ctx, tournament)
+}
+
+// getTournamentsHandler возвращает список всех турниров
+func getTournamentsHandler(ctx *fasthttp.RequestCtx) {
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	writeJSON(ctx, tournaments)
+}
+
+// findTournament возвращает указатель на турнир по ID (вызывающий должен держать tournamentMutex)
+func findTournament(id int) *Tournament {
+	for i := range tournaments {
+		if tournaments[i].ID == id {
+			return &tournaments[i]
+		}
+	}
+	return nil
+}
+
+// joinTournamentHandler регистрирует игрока в турнире, ожидающем старта
+func joinTournamentHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		PlayerID int `json:"player_id"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+
+	if authPlayerID, _ := authenticatedPlayerID(ctx); authPlayerID != body.PlayerID {
+		ctx.Error("Можно регистрировать в турнире только себя", fasthttp.StatusForbidden)
+		return
+	}
+
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	tournament := findTournament(id)
+	if tournament == nil {
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
+		return
+	}
+
+	if tournament.Status != "pending" {
+		ctx.Error("Турнир уже начался", fasthttp.StatusBadRequest)
+		return
+	}
+
+	var player *Player
+	for i := range players {
+		if players[i].ID == body.PlayerID {
+			player = &players[i]
+			break
+		}
+	}
+	if player == nil {
+		ctx.Error("Игрок не найден", fasthttp.StatusNotFound)
+		return
+	}
+
+	for _, p := range tournamentParticipants {
+		if p.TournamentID == id && p.PlayerID == body.PlayerID {
+			ctx.Error("Игрок уже зарегистрирован в турнире", fasthttp.StatusBadRequest)
+			return
+		}
+	}
+
+	participant := TournamentParticipant{
+		TournamentID: id,
+		PlayerID:     body.PlayerID,
+		Seed:         countTournamentParticipants(id) + 1,
+	}
+	tournamentParticipants = append(tournamentParticipants, participant)
+	persistTournamentParticipant(participant)
+
+	writeJSON(ctx, participant)
+}
+
+// countTournamentParticipants возвращает число реальных участников турнира (без баев)
+func countTournamentParticipants(tournamentID int) int {
+	count := 0
+	for _, p := range tournamentParticipants {
+		if p.TournamentID == tournamentID && !p.IsBye {
+			count++
+		}
+	}
+	return count
+}
+
+// nextPowerOfTwo возвращает наименьшую степень двойки, не меньшую n
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p *= 2
+	}
+	return p
+}
+
+// startTournamentHandler засеивает сетку на основе зарегистрированных игроков и создает раунд 1
+func startTournamentHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	tournament := findTournament(id)
+	if tournament == nil {
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
+		return
+	}
+
+	if tournament.Status != "pending" {
+		ctx.Error("Турнир уже начат", fasthttp.StatusBadRequest)
+		return
+	}
+
+	var seeds []TournamentParticipant
+	for _, p := range tournamentParticipants {
+		if p.TournamentID == id {
+			seeds = append(seeds, p)
+		}
+	}
+
+	if len(seeds) < 2 {
+		ctx.Error("Недостаточно игроков для старта турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	// Сортируем по рейтингу, чтобы засев был детерминированным
+	sort.Slice(seeds, func(i, j int) bool {
+		return playerRating(seeds[i].PlayerID) > playerRating(seeds[j].PlayerID)
+	})
+
+	// Дополняем баями до следующей степени двойки
+	bracketSize := nextPowerOfTwo(len(seeds))
+	for len(seeds) < bracketSize {
+		byeSeed := TournamentParticipant{
+			TournamentID: id,
+			PlayerID:     0,
+			Seed:         len(seeds) + 1,
+			IsBye:        true,
+		}
+		seeds = append(seeds, byeSeed)
+		tournamentParticipants = append(tournamentParticipants, byeSeed)
+		persistTournamentParticipant(byeSeed)
+	}
+
+	totalRounds := 0
+	for size := bracketSize; size > 1; size /= 2 {
+		totalRounds++
+	}
+	tournament.Rounds = totalRounds
+	tournament.Current = 1
+	tournament.Status = "active"
+	persistTournament(*tournament)
+
+	// Раунд 1: стандартное для одиночного выбывания сопоставление 1 vs N, 2 vs N-1, ...
+	for i := 0; i < bracketSize/2; i++ {
+		a := seeds[i]
+		b := seeds[bracketSize-1-i]
+
+		round := TournamentRound{
+			TournamentID: id,
+			Round:        1,
+			Match:        i + 1,
+			PlayerAID:    a.PlayerID,
+			PlayerBID:    b.PlayerID,
+			Status:       "pending",
+		}
+
+		if a.IsBye || b.IsBye {
+			// Бай: оставшийся игрок автоматически проходит дальше
+			round.Status = "bye"
+			round.Reported = true
+			if a.IsBye {
+				round.WinnerID = b.PlayerID
+			} else {
+				round.WinnerID = a.PlayerID
+			}
+		}
+
+		tournamentRounds = append(tournamentRounds, round)
+		persistTournamentRound(round)
+	}
+
+	advanceByeWinners(tournament)
+
+	writeJSON(ctx, tournament)
+}
+
+// playerRating возвращает текущий рейтинг игрока (1500, если игрок не найден)
+func playerRating(playerID int) int {
+	for _, p := range players {
+		if p.ID == playerID {
+			return p.Rating
+		}
+	}
+	return 1500
+}
+
+// getTournamentRoundsHandler возвращает все сыгранные и предстоящие матчи турнира
+func getTournamentRoundsHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	var rounds []TournamentRound
+	for _, round := range tournamentRounds {
+		if round.TournamentID == id {
+			rounds = append(rounds, round)
+		}
+	}
+
+	writeJSON(ctx, rounds)
+}
+
+// reportTournamentResultHandler принимает финальный счет матча Memory между двумя игроками
+// турнирного раунда и продвигает победителя дальше по сетке
+func reportTournamentResultHandler(ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID турнира", fasthttp.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Round  int `json:"round"`
+		Match  int `json:"match"`
+		ScoreA int `json:"score_a"`
+		ScoreB int `json:"score_b"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+
+	tournamentMutex.Lock()
+	defer tournamentMutex.Unlock()
+
+	tournament := findTournament(id)
+	if tournament == nil {
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
+		return
+	}
+
+	var match *TournamentRound
+	for i := range tournamentRounds {
+		m := &tournamentRounds[i]
+		if m.TournamentID == id && m.Round == body.Round && m.Match == body.Match {
+			match = m
+			break
+		}
+	}
+
+	if match == nil {
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
+		return
+	}
+
+	if authPlayerID, _ := authenticatedPlayerID(ctx); authPlayerID != match.PlayerAID && authPlayerID != match.PlayerBID {
+		ctx.Error("Сообщать результат может только участник матча", fasthttp.StatusForbidden)
+		return
+	}
+
+	if match.Reported {
+		ctx.Error("Результат матча уже зафиксирован", fasthttp.StatusBadRequest)
+		return
+	}
+
+	match.ScoreA = body.ScoreA
+	match.ScoreB = body.ScoreB
+	match.Reported = true
+	match.Status = "reported"
+
+	if body.ScoreA > body.ScoreB {
+		match.WinnerID = match.PlayerAID
+	} else if body.ScoreB > body.ScoreA {
+		match.WinnerID = match.PlayerBID
+	} else {
+		// Ничьих в одиночном выбывании быть не должно, оставляем раунд переигранным
+		match.Reported = false
+		match.Status = "pending"
+		ctx.Error("Ничья недопустима, переиграйте матч", fasthttp.StatusBadRequest)
+		return
+	}
+
+	persistTournamentRound(*match)
+	applyEloForMatch(tournament.ID, match.PlayerAID, match.PlayerBID, match.WinnerID)
+	advanceIfRoundComplete(tournament)
+
+	writeJSON(ctx, match)
+}
+
+// advanceByeWinners сразу продвигает баи в следующий раунд, если весь текущий раунд состоит из баев
+func advanceByeWinners(tournament *Tournament) {
+	advanceIfRoundComplete(tournament)
+}
+
+// roundMatches возвращает матчи данного раунда турнира
+func roundMatches(tournamentID, round int) []*TournamentRound {
+	var matches []*TournamentRound
+	for i := range tournamentRounds {
+		if tournamentRounds[i].TournamentID == tournamentID && tournamentRounds[i].Round == round {
+			matches = append(matches, &tournamentRounds[i])
+		}
+	}
+	return matches
+}
+
+// advanceIfRoundComplete генерирует следующий раунд, когда все матчи текущего раунда отыграны,
+// либо завершает турнир, если это был финал
+func advanceIfRoundComplete(tournament *Tournament) {
+	current := roundMatches(tournament.ID, tournament.Current)
+	for _, m := range current {
+		if !m.Reported {
+			return
+		}
+	}
+
+	if len(current) == 1 {
+		tournament.Status = "finished"
+		persistTournament(*tournament)
+		return
+	}
+
+	nextRound := tournament.Current + 1
+	// Матчи следующего раунда берутся не из соседних по номеру матчей текущего раунда,
+	// а зеркально (current[i] с current[len-1-i]) — та же схема, что и при засеве раунда 1.
+	// Иначе победители match 1 (seed 1) и match 2 (seed 2) встретились бы уже в полуфинале
+	// вместо финала.
+	for i := 0; i < len(current)/2; i++ {
+		a := current[i].WinnerID
+		b := current[len(current)-1-i].WinnerID
+
+		round := TournamentRound{
+			TournamentID: tournament.ID,
+			Round:        nextRound,
+			Match:        i + 1,
+			PlayerAID:    a,
+			PlayerBID:    b,
+			Status:       "pending",
+		}
+		tournamentRounds = append(tournamentRounds, round)
+		persistTournamentRound(round)
+	}
+
+	tournament.Current = nextRound
+	persistTournament(*tournament)
+
+	// Повторная проверка уже нового раунда: его матчи создаются неотыгранными (pending),
+	// поэтому она сразу возвращается
+	advanceIfRoundComplete(tournament)
+}
+
+// eloExpectedScore вычисляет ожидаемый результат E по формуле Эло
+func eloExpectedScore(rating, opponentRating int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponentRating-rating)/400))
+}
+
+// eloKFactor возвращает K-фактор: 32 для неопытных игроков, 16 после 30 рейтинговых партий
+func eloKFactor(ratedGames int) float64 {
+	if ratedGames >= unratedGamesLimit {
+		return ratedEloK
+	}
+	return unratedEloK
+}
+
+// applyEloForMatch обновляет рейтинг обоих игроков по итогам турнирного матча и пишет историю
+func applyEloForMatch(tournamentID, playerAID, playerBID, winnerID int) {
+	if playerAID == 0 || playerBID == 0 {
+		// Бай: сопернику начислять рейтинг не за что
 		return
 	}
 
-	http.NotFound(w, r)
+	// Rating/RatedGames — поля того же Player, что мутируют игровые обработчики под mutex,
+	// поэтому берем тот же mutex, а не отдельный lock для турнирных полей. Вызывающий держит
+	// только tournamentMutex, так что порядок захвата здесь всегда tournamentMutex -> mutex.
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var a, b *Player
+	for i := range players {
+		if players[i].ID == playerAID {
+			a = &players[i]
+		}
+		if players[i].ID == playerBID {
+			b = &players[i]
+		}
+	}
+	if a == nil || b == nil {
+		return
+	}
+
+	var scoreA, scoreB float64
+	switch winnerID {
+	case playerAID:
+		scoreA, scoreB = 1, 0
+	case playerBID:
+		scoreA, scoreB = 0, 1
+	default:
+		scoreA, scoreB = 0.5, 0.5
+	}
+
+	expectedA := eloExpectedScore(a.Rating, b.Rating)
+	expectedB := eloExpectedScore(b.Rating, a.Rating)
+
+	kA := eloKFactor(a.RatedGames)
+	kB := eloKFactor(b.RatedGames)
+
+	ratingBeforeA, ratingBeforeB := a.Rating, b.Rating
+
+	a.Rating = a.Rating + int(math.Round(kA*(scoreA-expectedA)))
+	b.Rating = b.Rating + int(math.Round(kB*(scoreB-expectedB)))
+	a.RatedGames++
+	b.RatedGames++
+
+	recordRatingHistory(a.ID, tournamentID, ratingBeforeA, a.Rating)
+	recordRatingHistory(b.ID, tournamentID, ratingBeforeB, b.Rating)
+	persistPlayer(*a)
+	persistPlayer(*b)
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// BenchmarkScriptedSixteenCardGame реплеит полную игру на сложном уровне (16 карт, 8 пар)
+// через fasthttp-роутер напрямую, без сетевого стека, чтобы измерить throughput/latency
+// обработчиков под нагрузкой. Колода перемешивается случайно при старте, поэтому раскладка
+// читается из ответа /start и карты переворачиваются настоящими совпадающими парами — это
+// единственный способ гарантированно дойти до allMatched и прогнать через бенчмарк весь путь
+// endGameForPlayer/checkAchievements, а не прерваться на первом же несовпадении.
+func BenchmarkScriptedSixteenCardGame(b *testing.B) {
+	games = make(map[int]GameState)
+	players = []Player{{ID: 1, Name: "Bench", Coins: 1 << 20, Rating: 1500}}
+
+	token, err := generateAccessToken(1)
+	if err != nil {
+		b.Fatalf("не удалось сгенерировать токен для бенчмарка: %v", err)
+	}
+	authHeader := "Bearer " + token
+
+	handler := newRouter().Handler
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		startCtx := new(fasthttp.RequestCtx)
+		startCtx.Request.Header.SetMethod("POST")
+		startCtx.Request.Header.Set("Authorization", authHeader)
+		startCtx.Request.SetRequestURI("/game/1/start?difficulty=hard")
+		handler(startCtx)
+
+		var game GameState
+		if err := json.Unmarshal(startCtx.Response.Body(), &game); err != nil {
+			b.Fatalf("не удалось разобрать состояние игры: %v", err)
+		}
+
+		pairs := make(map[string][]int)
+		for _, card := range game.Cards {
+			pairs[card.Value] = append(pairs[card.Value], card.ID)
+		}
+
+		for _, cardIDs := range pairs {
+			for _, cardID := range cardIDs {
+				flipCtx := new(fasthttp.RequestCtx)
+				flipCtx.Request.Header.SetMethod("POST")
+				flipCtx.Request.Header.Set("Authorization", authHeader)
+				flipCtx.Request.SetRequestURI("/game/1/flip/" + strconv.Itoa(cardID))
+				handler(flipCtx)
+			}
+		}
+
+		stateCtx := new(fasthttp.RequestCtx)
+		stateCtx.Request.Header.SetMethod("GET")
+		stateCtx.Request.Header.Set("Authorization", authHeader)
+		stateCtx.Request.SetRequestURI("/game/1/state")
+		handler(stateCtx)
+	}
+}
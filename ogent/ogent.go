@@ -0,0 +1,140 @@
+// Package ogent предоставляет REST-поверхность, которую ogen-go/ogent обычно генерирует из
+// ent/openapi.yaml. Этот модуль не тянет entgo.io/ent и ogen-go тулинг (см. ent/client.go),
+// поэтому операции, описанные в openapi.yaml (listPlayer, createPlayer, readPlayer,
+// listGameSession), реализованы здесь вручную поверх того же типобезопасного ent.Client —
+// документированный CRUD-слой с пагинацией и eager loading рядом с уже существующими игровыми
+// эндпоинтами, а не просто неиспользуемая спецификация.
+package ogent
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"casino/ent"
+)
+
+func writeJSON(ctx *fasthttp.RequestCtx, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		ctx.Error("Ошибка кодирования JSON", fasthttp.StatusInternalServerError)
+		return
+	}
+	ctx.SetContentType("application/json")
+	ctx.Write(body)
+}
+
+func queryInt(ctx *fasthttp.RequestCtx, key string, def int) int {
+	raw := string(ctx.QueryArgs().Peek(key))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// playerList — форма ответа PlayerList из openapi.yaml
+type playerList struct {
+	Items      []*ent.Player `json:"items"`
+	Page       int           `json:"page"`
+	TotalItems int           `json:"totalItems"`
+}
+
+// ListPlayer реализует operationId listPlayer: страница игроков с фильтром по rating
+// и опциональной подгрузкой достижений через ?include=achievements
+func ListPlayer(client *ent.Client, ctx *fasthttp.RequestCtx) {
+	opts := ent.PlayerListOptions{
+		Page:             queryInt(ctx, "page", 1),
+		ItemsPerPage:     queryInt(ctx, "itemsPerPage", 20),
+		WithAchievements: string(ctx.QueryArgs().Peek("include")) == "achievements",
+	}
+	if raw := string(ctx.QueryArgs().Peek("rating")); raw != "" {
+		if rating, err := strconv.Atoi(raw); err == nil {
+			opts.Rating = &rating
+		}
+	}
+
+	players, total, err := client.Player().List(opts)
+	if err != nil {
+		ctx.Error("Ошибка получения игроков", fasthttp.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(ctx, playerList{Items: players, Page: opts.Page, TotalItems: total})
+}
+
+// CreatePlayer реализует operationId createPlayer из PlayerCreate
+func CreatePlayer(client *ent.Client, ctx *fasthttp.RequestCtx) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(ctx.PostBody(), &body); err != nil || body.Name == "" {
+		ctx.Error("Некорректный JSON", fasthttp.StatusBadRequest)
+		return
+	}
+
+	player, err := client.Player().Create(body.Name)
+	if err != nil {
+		ctx.Error("Ошибка создания игрока", fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetStatusCode(201)
+	writeJSON(ctx, player)
+}
+
+// ReadPlayer реализует operationId readPlayer с опциональной подгрузкой достижений
+func ReadPlayer(client *ent.Client, ctx *fasthttp.RequestCtx) {
+	idStr, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		ctx.Error("Некорректный ID", fasthttp.StatusBadRequest)
+		return
+	}
+
+	player, err := client.Player().Get(id)
+	if err != nil {
+		ctx.Error("Не найдено", fasthttp.StatusNotFound)
+		return
+	}
+
+	if string(ctx.QueryArgs().Peek("include")) == "achievements" {
+		if achievements, err := client.Achievement().ListByPlayer(id); err == nil {
+			player.Edges.Achievements = achievements
+		}
+	}
+
+	writeJSON(ctx, player)
+}
+
+// gameSessionList — форма ответа GameSessionList из openapi.yaml
+type gameSessionList struct {
+	Items      []*ent.GameSession `json:"items"`
+	Page       int                `json:"page"`
+	TotalItems int                `json:"totalItems"`
+}
+
+// ListGameSession реализует operationId listGameSession с фильтром по finished
+func ListGameSession(client *ent.Client, ctx *fasthttp.RequestCtx) {
+	opts := ent.GameSessionListOptions{
+		Page:         queryInt(ctx, "page", 1),
+		ItemsPerPage: queryInt(ctx, "itemsPerPage", 20),
+	}
+	if raw := string(ctx.QueryArgs().Peek("finished")); raw != "" {
+		if finished, err := strconv.ParseBool(raw); err == nil {
+			opts.Finished = &finished
+		}
+	}
+
+	sessions, total, err := client.GameSession().List(opts)
+	if err != nil {
+		ctx.Error("Ошибка получения игровых сессий", fasthttp.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(ctx, gameSessionList{Items: sessions, Page: opts.Page, TotalItems: total})
+}